@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/mlguerrero12/pf-status-relay/pkg/config"
+	"github.com/mlguerrero12/pf-status-relay/pkg/lacp"
+	"github.com/mlguerrero12/pf-status-relay/pkg/log"
+)
+
+const socketPath = "/run/pf-status-relay/control.sock"
+
+// startControlSocket serves a line-oriented protocol over a Unix domain
+// socket, letting operators manage the running daemon without a restart:
+//
+//	reload         - re-read the config file and reconcile the managed PFs
+//	list           - print each managed interface and whether it is monitored
+//	pause <iface>  - stop monitoring an interface without un-managing it
+//	resume <iface> - restart monitoring a paused interface
+func startControlSocket(ctx context.Context, pfs *lacp.Interfaces, wg *sync.WaitGroup) error {
+	if err := os.MkdirAll(filepath.Dir(socketPath), 0o755); err != nil {
+		return fmt.Errorf("failed to create control socket directory: %w", err)
+	}
+
+	// Remove a stale socket left behind by a previous, uncleanly stopped run.
+	os.Remove(socketPath)
+
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return fmt.Errorf("failed to listen on control socket: %w", err)
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer os.Remove(socketPath)
+
+		go func() {
+			<-ctx.Done()
+			listener.Close()
+		}()
+
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				log.Log.Warn("failed to accept control connection", "error", err)
+				continue
+			}
+
+			go handleControlConn(ctx, conn, pfs)
+		}
+	}()
+
+	log.Log.Info("control socket listening", "path", socketPath)
+
+	return nil
+}
+
+func handleControlConn(ctx context.Context, conn net.Conn, pfs *lacp.Interfaces) {
+	defer conn.Close()
+
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		fmt.Fprintln(conn, handleControlCommand(ctx, fields, pfs))
+	}
+}
+
+func handleControlCommand(ctx context.Context, fields []string, pfs *lacp.Interfaces) string {
+	switch cmd, args := fields[0], fields[1:]; cmd {
+	case "reload":
+		conf, err := config.TryReadConfig()
+		if err != nil {
+			return fmt.Sprintf("error: %s", err)
+		}
+
+		pfs.Reconcile(ctx, conf)
+		return "ok"
+	case "list":
+		status := pfs.List()
+		if len(status) == 0 {
+			return "no interfaces managed"
+		}
+
+		lines := make([]string, 0, len(status))
+		for name, monitoring := range status {
+			state := "paused"
+			if monitoring {
+				state = "monitoring"
+			}
+			lines = append(lines, fmt.Sprintf("%s: %s", name, state))
+		}
+		return strings.Join(lines, "\n")
+	case "pause":
+		if len(args) != 1 {
+			return "error: usage: pause <iface>"
+		}
+
+		if err := pfs.Pause(args[0]); err != nil {
+			return fmt.Sprintf("error: %s", err)
+		}
+		return "ok"
+	case "resume":
+		if len(args) != 1 {
+			return "error: usage: resume <iface>"
+		}
+
+		if err := pfs.Resume(ctx, args[0]); err != nil {
+			return fmt.Sprintf("error: %s", err)
+		}
+		return "ok"
+	default:
+		return fmt.Sprintf("error: unknown command %q", cmd)
+	}
+}
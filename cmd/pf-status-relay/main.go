@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/mlguerrero12/pf-status-relay/pkg/config"
+	"github.com/mlguerrero12/pf-status-relay/pkg/events"
+	"github.com/mlguerrero12/pf-status-relay/pkg/lacp"
+	"github.com/mlguerrero12/pf-status-relay/pkg/log"
+	"github.com/mlguerrero12/pf-status-relay/pkg/metrics"
+	"github.com/mlguerrero12/pf-status-relay/pkg/subscribe"
+)
+
+func main() {
+	log.Log.Info("application started")
+
+	// Capture SIGINT and SIGTERM
+	c := make(chan os.Signal, 2)
+	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+
+	// Read config file.
+	conf := config.ReadConfig()
+
+	// Serve Prometheus metrics, if configured.
+	go metrics.Start(conf.MetricsAddress)
+
+	// Build the event journal operator-visible LACP/VF events are sent to.
+	journal, err := events.NewJournalFromConfig(conf.EventSinks)
+	if err != nil {
+		log.Log.Error("failed to build event journal", "error", err)
+		os.Exit(1)
+	}
+	defer journal.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// Queue to store link events.
+	queue := make(chan int, 100)
+
+	var wg sync.WaitGroup
+
+	// Initialize PFs.
+	pfs := lacp.New(conf.Interfaces, conf.PollingInterval, conf.PollingMode, conf.Action, conf.ScriptPath, journal)
+	if len(pfs.PFs) == 0 {
+		log.Log.Error("no interfaces found in node")
+		os.Exit(1)
+	}
+
+	// Start LACP inspection and processing.
+	pfs.Start(ctx, queue, &wg)
+
+	// Subscribe to link changes.
+	err = subscribe.Start(ctx, pfs.Indexes(), queue, &wg)
+	if err != nil {
+		log.Log.Error("failed to subscribe to link changes", "error", err)
+	}
+
+	// Reload the managed PF set whenever the config file changes on disk.
+	err = config.Watch(ctx, func(newConf config.Config) {
+		pfs.Reconcile(ctx, newConf)
+	})
+	if err != nil {
+		log.Log.Error("failed to watch config file", "error", err)
+	}
+
+	// Serve the control socket for reload/list/pause/resume commands.
+	err = startControlSocket(ctx, pfs, &wg)
+	if err != nil {
+		log.Log.Error("failed to start control socket", "error", err)
+	}
+
+	<-c
+	cancel()
+	wg.Wait()
+}
@@ -1,8 +1,13 @@
 package config
 
 import (
+	"context"
+	"fmt"
 	"os"
+	"path/filepath"
+	"time"
 
+	"github.com/fsnotify/fsnotify"
 	"gopkg.in/yaml.v2"
 
 	"github.com/mlguerrero12/pf-status-relay/pkg/log"
@@ -12,37 +17,162 @@ const path = "/etc/pf-status-relay/config.yaml"
 
 // Config contains the configuration of the application.
 type Config struct {
-	Interfaces      []string `yaml:"interfaces"`
-	PollingInterval int      `yaml:"pollingInterval"`
+	Interfaces      []InterfaceConfig `yaml:"interfaces"`
+	PollingInterval int               `yaml:"pollingInterval"`
+	// MetricsAddress is the address (e.g. ":9100") the Prometheus metrics
+	// endpoint listens on. Leave empty to disable it.
+	MetricsAddress string `yaml:"metricsAddress"`
+	// PollingMode switches LACP/VF monitoring back to fixed-interval polling
+	// instead of the default netlink subscription, for kernels that don't
+	// emit slave attribute change notifications.
+	PollingMode bool `yaml:"pollingMode"`
+	// Action selects how the daemon reacts to LACP state changes: "vfstate"
+	// (default), "trust", "spoofcheck", "rate" or "script".
+	Action string `yaml:"action"`
+	// ScriptPath is the hook script exec'd by the "script" action.
+	ScriptPath string `yaml:"scriptPath"`
+	// EventSinks configures where operator-visible LACP/VF events are sent.
+	// Defaults to a single stdout JSON sink when empty.
+	EventSinks []SinkConfig `yaml:"eventSinks"`
 }
 
-// ReadConfig read yaml config file.
+// InterfaceConfig names a managed interface and its VF handling policy.
+type InterfaceConfig struct {
+	Name     string   `yaml:"name"`
+	VFPolicy VFPolicy `yaml:"vfPolicy"`
+}
+
+// VFPolicy controls which of an interface's VFs are touched and how
+// quickly they react to LACP edges.
+type VFPolicy struct {
+	// ExcludeVFs lists VF ids the daemon never toggles, e.g. ones assigned
+	// to a management workload.
+	ExcludeVFs []int `yaml:"excludeVFs"`
+	// Groups names subsets of VFs, by id, that are reacted to as
+	// independent units instead of all-or-nothing. VFs not listed in any
+	// group still form a catch-all group of their own.
+	Groups map[string][]int `yaml:"groups"`
+	// DownDebounce requires LACP-down to persist for this long before VFs
+	// are disabled, filtering brief partner glitches.
+	DownDebounce time.Duration `yaml:"downDebounce"`
+	// UpDelay requires LACP-up to be stable for this long before VFs are
+	// re-enabled.
+	UpDelay time.Duration `yaml:"upDelay"`
+}
+
+// SinkConfig configures a single event sink.
+type SinkConfig struct {
+	// Type selects the sink implementation: "stdout" (default), "file" or
+	// "syslog".
+	Type string `yaml:"type"`
+
+	// Path, MaxSizeMB, MaxAgeDays and MaxBackups configure the "file" sink.
+	Path       string `yaml:"path"`
+	MaxSizeMB  int    `yaml:"maxSizeMB"`
+	MaxAgeDays int    `yaml:"maxAgeDays"`
+	MaxBackups int    `yaml:"maxBackups"`
+
+	// Network and Address configure the "syslog" sink, e.g. "udp" and
+	// "syslog.example.com:514".
+	Network string `yaml:"network"`
+	Address string `yaml:"address"`
+}
+
+// ReadConfig read yaml config file. It exits the process if the config file
+// is missing or invalid, since there is nothing useful to run without one.
 func ReadConfig() Config {
-	data, err := os.ReadFile(path)
+	c, err := TryReadConfig()
 	if err != nil {
 		log.Log.Error("failed to read config file", "error", err)
 		os.Exit(1)
 	}
 
+	return c
+}
+
+// TryReadConfig reads and validates the config file without exiting the
+// process, for callers that need to reload the config on a live daemon,
+// such as Watch and the control socket's reload command.
+func TryReadConfig() (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to read config file: %w", err)
+	}
+
 	c := Config{
 		PollingInterval: 1000,
 	}
 
 	err = yaml.Unmarshal(data, &c)
 	if err != nil {
-		log.Log.Error("failed to unmarshall config file", "error", err)
-		os.Exit(1)
+		return Config{}, fmt.Errorf("failed to unmarshal config file: %w", err)
 	}
 
 	if c.Interfaces == nil {
-		log.Log.Error("failed to parse config file", "error", "no interfaces found")
-		os.Exit(1)
+		return Config{}, fmt.Errorf("no interfaces found")
 	}
 
 	if c.PollingInterval <= 0 {
-		log.Log.Error("failed to parse config file", "error", "invalid polling interval")
-		os.Exit(1)
+		return Config{}, fmt.Errorf("invalid polling interval")
 	}
 
-	return c
+	return c, nil
+}
+
+// Watch watches the config file for changes and calls onChange with the
+// freshly parsed Config whenever it is written. A malformed config on reload
+// is logged and ignored rather than applied, so the daemon keeps running
+// with its last good configuration. The watch stops when ctx is cancelled.
+func Watch(ctx context.Context, onChange func(Config)) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create config watcher: %w", err)
+	}
+
+	// Watch the directory, not the file directly: editors and config
+	// management tools commonly replace the file (rename over it) rather
+	// than writing to it in place, which a file watch would miss.
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		watcher.Close()
+		return fmt.Errorf("failed to watch config directory: %w", err)
+	}
+
+	go func() {
+		defer watcher.Close()
+
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+
+				if filepath.Clean(event.Name) != path {
+					break
+				}
+
+				if event.Op&(fsnotify.Write|fsnotify.Create) == 0 {
+					break
+				}
+
+				c, err := TryReadConfig()
+				if err != nil {
+					log.Log.Error("failed to reload config file, keeping previous config", "error", err)
+					break
+				}
+
+				log.Log.Info("config file reloaded")
+				onChange(c)
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.Log.Error("config watcher error", "error", err)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return nil
 }
@@ -0,0 +1,69 @@
+// Package events records LACP state transitions and VF state changes as
+// structured, operator-visible events, independent of the developer-facing
+// logs written through pkg/log.
+package events
+
+import "time"
+
+// Event types recorded in the journal.
+const (
+	TypeLacpUp     = "lacp_up"
+	TypeLacpDown   = "lacp_down"
+	TypeVfState    = "vf_state_change"
+	TypeVfTrust    = "vf_trust_change"
+	TypeVfSpoofchk = "vf_spoofchk_change"
+	TypeVfRate     = "vf_rate_change"
+	TypeScriptHook = "script_hook"
+)
+
+// Event is a single structured record of an LACP state transition or VF
+// state change.
+type Event struct {
+	Time      time.Time `json:"time"`
+	Interface string    `json:"interface"`
+	Type      string    `json:"type"`
+	Before    string    `json:"before"`
+	After     string    `json:"after"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// Sink persists Events so they can be audited independently of the process
+// lifetime.
+type Sink interface {
+	Record(Event)
+	Close() error
+}
+
+// Journal fans an Event out to every configured Sink.
+type Journal struct {
+	sinks []Sink
+}
+
+// NewJournal returns a Journal that records to all the given sinks.
+func NewJournal(sinks ...Sink) *Journal {
+	return &Journal{sinks: sinks}
+}
+
+// Record stamps e with the current time, if unset, and writes it to every
+// sink.
+func (j *Journal) Record(e Event) {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	for _, s := range j.sinks {
+		s.Record(e)
+	}
+}
+
+// Close closes every sink, returning the first error encountered.
+func (j *Journal) Close() error {
+	var firstErr error
+	for _, s := range j.sinks {
+		if err := s.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
@@ -0,0 +1,47 @@
+package events
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/mlguerrero12/pf-status-relay/pkg/config"
+)
+
+// NewSink builds the Sink described by cfg.
+func NewSink(cfg config.SinkConfig) (Sink, error) {
+	switch cfg.Type {
+	case "", "stdout":
+		return NewStdoutSink(os.Stdout), nil
+	case "file":
+		return NewFileSink(FileSinkConfig{
+			Path:       cfg.Path,
+			MaxSizeMB:  cfg.MaxSizeMB,
+			MaxAgeDays: cfg.MaxAgeDays,
+			MaxBackups: cfg.MaxBackups,
+		}), nil
+	case "syslog":
+		return NewSyslogSink(SyslogSinkConfig{Network: cfg.Network, Address: cfg.Address})
+	default:
+		return nil, fmt.Errorf("unknown event sink type %q", cfg.Type)
+	}
+}
+
+// NewJournalFromConfig builds a Journal from the configured sinks, defaulting
+// to a single stdout sink when none are configured so behavior is unchanged
+// out of the box.
+func NewJournalFromConfig(cfgs []config.SinkConfig) (*Journal, error) {
+	if len(cfgs) == 0 {
+		return NewJournal(NewStdoutSink(os.Stdout)), nil
+	}
+
+	sinks := make([]Sink, 0, len(cfgs))
+	for _, c := range cfgs {
+		s, err := NewSink(c)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, s)
+	}
+
+	return NewJournal(sinks...), nil
+}
@@ -0,0 +1,47 @@
+package events
+
+import (
+	"encoding/json"
+	"sync"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// FileSinkConfig configures rotation for a FileSink.
+type FileSinkConfig struct {
+	Path       string
+	MaxSizeMB  int
+	MaxAgeDays int
+	MaxBackups int
+}
+
+// FileSink writes each Event as a JSON line to a size/age/backup-rotated
+// file.
+type FileSink struct {
+	mu     sync.Mutex
+	logger *lumberjack.Logger
+	enc    *json.Encoder
+}
+
+// NewFileSink returns a FileSink rotating according to cfg.
+func NewFileSink(cfg FileSinkConfig) *FileSink {
+	logger := &lumberjack.Logger{
+		Filename:   cfg.Path,
+		MaxSize:    cfg.MaxSizeMB,
+		MaxAge:     cfg.MaxAgeDays,
+		MaxBackups: cfg.MaxBackups,
+	}
+
+	return &FileSink{logger: logger, enc: json.NewEncoder(logger)}
+}
+
+func (s *FileSink) Record(e Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_ = s.enc.Encode(e)
+}
+
+func (s *FileSink) Close() error {
+	return s.logger.Close()
+}
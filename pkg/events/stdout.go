@@ -0,0 +1,31 @@
+package events
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+)
+
+// StdoutSink writes each Event as a JSON line to an io.Writer. It is the
+// default sink, matching the JSON-on-stdout behavior the daemon has always
+// had.
+type StdoutSink struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewStdoutSink returns a StdoutSink writing to w.
+func NewStdoutSink(w io.Writer) *StdoutSink {
+	return &StdoutSink{enc: json.NewEncoder(w)}
+}
+
+func (s *StdoutSink) Record(e Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_ = s.enc.Encode(e)
+}
+
+func (s *StdoutSink) Close() error {
+	return nil
+}
@@ -0,0 +1,71 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// Facility/severity used for every message: local0/informational. LACP
+// events are informational by nature; operators that need finer-grained
+// severities can filter on the Type field carried in the message body.
+const syslogPriority = 16*8 + 6
+
+// SyslogSinkConfig configures the syslog destination a SyslogSink dials.
+type SyslogSinkConfig struct {
+	// Network is "udp" or "tcp".
+	Network string
+	Address string
+}
+
+// SyslogSink writes each Event as an RFC5424 syslog message.
+type SyslogSink struct {
+	mu       sync.Mutex
+	conn     net.Conn
+	hostname string
+	appName  string
+	pid      int
+}
+
+// NewSyslogSink dials the syslog destination described by cfg.
+func NewSyslogSink(cfg SyslogSinkConfig) (*SyslogSink, error) {
+	conn, err := net.Dial(cfg.Network, cfg.Address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial syslog at %s://%s: %w", cfg.Network, cfg.Address, err)
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "-"
+	}
+
+	return &SyslogSink{
+		conn:     conn,
+		hostname: hostname,
+		appName:  "pf-status-relay",
+		pid:      os.Getpid(),
+	}, nil
+}
+
+func (s *SyslogSink) Record(e Event) {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+
+	// RFC5424: <PRI>VERSION TIMESTAMP HOSTNAME APP-NAME PROCID MSGID SD MSG
+	line := fmt.Sprintf("<%d>1 %s %s %s %d %s - %s\n",
+		syslogPriority, e.Time.UTC().Format(time.RFC3339), s.hostname, s.appName, s.pid, e.Type, data)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, _ = s.conn.Write([]byte(line))
+}
+
+func (s *SyslogSink) Close() error {
+	return s.conn.Close()
+}
@@ -0,0 +1,227 @@
+package lacp
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/vishvananda/netlink"
+
+	"github.com/mlguerrero12/pf-status-relay/pkg/events"
+	"github.com/mlguerrero12/pf-status-relay/pkg/log"
+	"github.com/mlguerrero12/pf-status-relay/pkg/metrics"
+)
+
+// Action defines how a PF reacts to its LACP state. OnLacpUp is invoked on
+// every monitoring cycle while LACP is up, OnLacpDown while it is down, so
+// implementations are expected to be idempotent.
+type Action interface {
+	OnLacpUp(pf *PF, vfs []netlink.VfInfo) error
+	OnLacpDown(pf *PF, vfs []netlink.VfInfo) error
+}
+
+// newAction builds the Action configured for a PF, defaulting to
+// VFStateAction when name is empty or unrecognized.
+func newAction(name, scriptPath string) Action {
+	switch name {
+	case "", "vfstate":
+		return VFStateAction{}
+	case "trust":
+		return TrustAction{}
+	case "spoofcheck":
+		return SpoofCheckAction{}
+	case "rate":
+		return RateAction{}
+	case "script":
+		return ScriptAction{Path: scriptPath}
+	default:
+		log.Log.Warn("unknown action, defaulting to vfstate", "action", name)
+		return VFStateAction{}
+	}
+}
+
+// VFStateAction is the original action: it brings VFs to auto on LACP up
+// and disables them on LACP down via netlink.LinkSetVfState.
+type VFStateAction struct{}
+
+func (VFStateAction) OnLacpUp(pf *PF, vfs []netlink.VfInfo) error {
+	return setVfState(pf, vfs, netlink.VF_LINK_STATE_DISABLE, netlink.VF_LINK_STATE_AUTO, "auto")
+}
+
+func (VFStateAction) OnLacpDown(pf *PF, vfs []netlink.VfInfo) error {
+	return setVfState(pf, vfs, netlink.VF_LINK_STATE_AUTO, netlink.VF_LINK_STATE_DISABLE, "disable")
+}
+
+func setVfState(pf *PF, vfs []netlink.VfInfo, from, to uint32, toName string) error {
+	link, err := netlink.LinkByIndex(pf.Index)
+	if err != nil {
+		return fmt.Errorf("failed to fetch interface %s: %w", pf.Name, err)
+	}
+
+	for _, vf := range vfs {
+		if vf.LinkState != from {
+			continue
+		}
+
+		if err := netlink.LinkSetVfState(link, vf.ID, to); err != nil {
+			log.Log.Error("failed to set vf link state", "id", vf.ID, "interface", pf.Name, "error", err)
+			metrics.VfActionFailuresTotal.WithLabelValues(pf.Name, "vfstate").Inc()
+			pf.journal.Record(events.Event{Interface: pf.Name, Type: events.TypeVfState, Before: vfStateName(from), After: toName, Error: err.Error()})
+			continue
+		}
+		log.Log.Info("vf link state was set", "id", vf.ID, "state", toName, "interface", pf.Name)
+		pf.journal.Record(events.Event{Interface: pf.Name, Type: events.TypeVfState, Before: vfStateName(from), After: toName})
+	}
+
+	return nil
+}
+
+// vfStateName returns the human-readable name of a netlink VF link state.
+func vfStateName(state uint32) string {
+	if state == netlink.VF_LINK_STATE_DISABLE {
+		return "disable"
+	}
+	return "auto"
+}
+
+// TrustAction toggles each VF's trust flag: trusted while LACP is up,
+// untrusted while it is down.
+type TrustAction struct{}
+
+func (TrustAction) OnLacpUp(pf *PF, vfs []netlink.VfInfo) error {
+	return setVfTrust(pf, vfs, true)
+}
+
+func (TrustAction) OnLacpDown(pf *PF, vfs []netlink.VfInfo) error {
+	return setVfTrust(pf, vfs, false)
+}
+
+func setVfTrust(pf *PF, vfs []netlink.VfInfo, trust bool) error {
+	link, err := netlink.LinkByIndex(pf.Index)
+	if err != nil {
+		return fmt.Errorf("failed to fetch interface %s: %w", pf.Name, err)
+	}
+
+	for _, vf := range vfs {
+		if err := netlink.LinkSetVfTrust(link, vf.ID, trust); err != nil {
+			log.Log.Error("failed to set vf trust", "id", vf.ID, "interface", pf.Name, "error", err)
+			metrics.VfActionFailuresTotal.WithLabelValues(pf.Name, "trust").Inc()
+			pf.journal.Record(events.Event{Interface: pf.Name, Type: events.TypeVfTrust, Before: strconv.FormatBool(!trust), After: strconv.FormatBool(trust), Error: err.Error()})
+			continue
+		}
+		log.Log.Info("vf trust was set", "id", vf.ID, "trust", trust, "interface", pf.Name)
+		pf.journal.Record(events.Event{Interface: pf.Name, Type: events.TypeVfTrust, Before: strconv.FormatBool(!trust), After: strconv.FormatBool(trust)})
+	}
+
+	return nil
+}
+
+// SpoofCheckAction toggles each VF's spoof check: disabled while LACP is
+// up, enabled while it is down as the partner link can no longer be relied
+// on to police traffic.
+type SpoofCheckAction struct{}
+
+func (SpoofCheckAction) OnLacpUp(pf *PF, vfs []netlink.VfInfo) error {
+	return setVfSpoofchk(pf, vfs, false)
+}
+
+func (SpoofCheckAction) OnLacpDown(pf *PF, vfs []netlink.VfInfo) error {
+	return setVfSpoofchk(pf, vfs, true)
+}
+
+func setVfSpoofchk(pf *PF, vfs []netlink.VfInfo, check bool) error {
+	link, err := netlink.LinkByIndex(pf.Index)
+	if err != nil {
+		return fmt.Errorf("failed to fetch interface %s: %w", pf.Name, err)
+	}
+
+	for _, vf := range vfs {
+		if err := netlink.LinkSetVfSpoofchk(link, vf.ID, check); err != nil {
+			log.Log.Error("failed to set vf spoofchk", "id", vf.ID, "interface", pf.Name, "error", err)
+			metrics.VfActionFailuresTotal.WithLabelValues(pf.Name, "spoofcheck").Inc()
+			pf.journal.Record(events.Event{Interface: pf.Name, Type: events.TypeVfSpoofchk, Before: strconv.FormatBool(!check), After: strconv.FormatBool(check), Error: err.Error()})
+			continue
+		}
+		log.Log.Info("vf spoofchk was set", "id", vf.ID, "spoofchk", check, "interface", pf.Name)
+		pf.journal.Record(events.Event{Interface: pf.Name, Type: events.TypeVfSpoofchk, Before: strconv.FormatBool(!check), After: strconv.FormatBool(check)})
+	}
+
+	return nil
+}
+
+// RateAction zeroes each VF's max_tx_rate on LACP down, on the assumption
+// that a dead LACP partner should not keep sinking traffic into a VF at
+// full rate. It has nothing to undo on LACP up, since the prior rate is not
+// tracked.
+type RateAction struct{}
+
+func (RateAction) OnLacpUp(pf *PF, vfs []netlink.VfInfo) error {
+	return nil
+}
+
+func (RateAction) OnLacpDown(pf *PF, vfs []netlink.VfInfo) error {
+	link, err := netlink.LinkByIndex(pf.Index)
+	if err != nil {
+		return fmt.Errorf("failed to fetch interface %s: %w", pf.Name, err)
+	}
+
+	for _, vf := range vfs {
+		if err := netlink.LinkSetVfRate(link, vf.ID, 0, 0); err != nil {
+			log.Log.Error("failed to set vf rate", "id", vf.ID, "interface", pf.Name, "error", err)
+			metrics.VfActionFailuresTotal.WithLabelValues(pf.Name, "rate").Inc()
+			pf.journal.Record(events.Event{Interface: pf.Name, Type: events.TypeVfRate, Before: "unset", After: "0", Error: err.Error()})
+			continue
+		}
+		log.Log.Info("vf max_tx_rate was zeroed", "id", vf.ID, "interface", pf.Name)
+		pf.journal.Record(events.Event{Interface: pf.Name, Type: events.TypeVfRate, Before: "unset", After: "0"})
+	}
+
+	return nil
+}
+
+// ScriptAction execs an operator-provided hook script on every reaction,
+// passing the PF name, LACP state and affected VF ids as environment
+// variables.
+type ScriptAction struct {
+	Path string
+}
+
+func (a ScriptAction) OnLacpUp(pf *PF, vfs []netlink.VfInfo) error {
+	return a.run(pf, vfs, "up")
+}
+
+func (a ScriptAction) OnLacpDown(pf *PF, vfs []netlink.VfInfo) error {
+	return a.run(pf, vfs, "down")
+}
+
+func (a ScriptAction) run(pf *PF, vfs []netlink.VfInfo, state string) error {
+	if a.Path == "" {
+		return fmt.Errorf("script action has no script configured")
+	}
+
+	ids := make([]string, 0, len(vfs))
+	for _, vf := range vfs {
+		ids = append(ids, strconv.Itoa(vf.ID))
+	}
+
+	cmd := exec.Command(a.Path)
+	cmd.Env = append(os.Environ(),
+		"PF_NAME="+pf.Name,
+		"LACP_STATE="+state,
+		"VF_IDS="+strings.Join(ids, ","),
+	)
+
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		log.Log.Error("hook script failed", "interface", pf.Name, "state", state, "error", err, "output", string(out))
+		metrics.VfActionFailuresTotal.WithLabelValues(pf.Name, "script").Inc()
+		pf.journal.Record(events.Event{Interface: pf.Name, Type: events.TypeScriptHook, After: state, Error: err.Error()})
+		return err
+	}
+
+	log.Log.Info("hook script executed", "interface", pf.Name, "state", state)
+	pf.journal.Record(events.Event{Interface: pf.Name, Type: events.TypeScriptHook, After: state})
+	return nil
+}
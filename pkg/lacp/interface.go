@@ -2,43 +2,68 @@ package lacp
 
 import (
 	"context"
+	"fmt"
 	"sync"
 
 	"github.com/vishvananda/netlink"
 
+	"github.com/mlguerrero12/pf-status-relay/pkg/config"
+	"github.com/mlguerrero12/pf-status-relay/pkg/events"
 	"github.com/mlguerrero12/pf-status-relay/pkg/log"
 )
 
 // Interfaces stores the PFs that are inspected.
 type Interfaces struct {
 	PFs map[int]*PF
+
+	// mu guards PFs against concurrent access from the link-change
+	// processing goroutine started by Start and Reconcile/Pause/Resume
+	// calls coming from the control socket.
+	mu sync.Mutex
+
+	// journal records LACP/VF events for every PF managed by Interfaces. It
+	// is built once at startup and shared across reconciliations, since its
+	// sinks (e.g. an open log file) are not cheap to re-open on every
+	// config reload.
+	journal *events.Journal
 }
 
-// New returns a Interfaces structure with interfaces that are found in the node.
-func New(nics []string, pollingInterval int) Interfaces {
-	i := Interfaces{PFs: make(map[int]*PF)}
-	for _, name := range nics {
-		link, err := netlink.LinkByName(name)
+// New returns an Interfaces structure with interfaces that are found in the
+// node. It returns a pointer since Interfaces owns a mutex, which must never
+// be copied.
+func New(nics []config.InterfaceConfig, pollingInterval int, pollingMode bool, action, scriptPath string, journal *events.Journal) *Interfaces {
+	i := &Interfaces{PFs: make(map[int]*PF), journal: journal}
+	for _, nic := range nics {
+		link, err := netlink.LinkByName(nic.Name)
 		if err != nil {
-			log.Log.Warn("failed to fetch interface", "interface", name, "error", err)
+			log.Log.Warn("failed to fetch interface", "interface", nic.Name, "error", err)
 			continue
 		}
 
-		log.Log.Debug("adding interface", "interface", name)
-
-		i.PFs[link.Attrs().Index] = &PF{
-			Name:        link.Attrs().Name,
-			Index:       link.Attrs().Index,
-			OperState:   link.Attrs().OperState,
-			MasterIndex: link.Attrs().MasterIndex,
+		log.Log.Debug("adding interface", "interface", nic.Name)
 
-			pollingInterval: pollingInterval,
-		}
+		i.PFs[link.Attrs().Index] = newPF(link, pollingInterval, pollingMode, action, scriptPath, nic.VFPolicy, journal)
 	}
 
 	return i
 }
 
+// newPF builds a PF from a fetched link and the settings that apply to it.
+func newPF(link netlink.Link, pollingInterval int, pollingMode bool, action, scriptPath string, vfPolicy config.VFPolicy, journal *events.Journal) *PF {
+	return &PF{
+		Name:        link.Attrs().Name,
+		Index:       link.Attrs().Index,
+		OperState:   link.Attrs().OperState,
+		MasterIndex: link.Attrs().MasterIndex,
+
+		pollingInterval: pollingInterval,
+		pollingMode:     pollingMode,
+		action:          newAction(action, scriptPath),
+		vfPolicy:        vfPolicy,
+		journal:         journal,
+	}
+}
+
 // Start starts LACP inspection and processing.
 func (i *Interfaces) Start(ctx context.Context, queue <-chan int, wg *sync.WaitGroup) {
 	log.Log.Debug("LACP inspection and processing started")
@@ -62,7 +87,13 @@ func (i *Interfaces) Start(ctx context.Context, queue <-chan int, wg *sync.WaitG
 			select {
 			case index := <-queue:
 				log.Log.Debug("processing event", "index", index)
-				p := i.PFs[index]
+				i.mu.Lock()
+				p, ok := i.PFs[index]
+				i.mu.Unlock()
+				if !ok {
+					break
+				}
+
 				updated, err := p.Update()
 				if err != nil {
 					log.Log.Error("failed to update link", "interface", p.Name)
@@ -91,6 +122,9 @@ func (i *Interfaces) Start(ctx context.Context, queue <-chan int, wg *sync.WaitG
 
 // Indexes returns a list of indexes.
 func (i *Interfaces) Indexes() []int {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
 	indexes := make([]int, 0, len(i.PFs))
 	for index := range i.PFs {
 		indexes = append(indexes, index)
@@ -98,3 +132,132 @@ func (i *Interfaces) Indexes() []int {
 
 	return indexes
 }
+
+// Reconcile diffs newCfg against the currently managed PF set: interfaces no
+// longer listed are stopped and dropped, newly listed ones are fetched and
+// started, and the rest have their polling interval/mode, action and VF
+// policy updated in place through PF.updateSettings, so e.g. switching
+// Config.Action from "vfstate" to "script" takes effect on reload without a
+// restart. A polling mode change only applies the next time a PF's
+// monitoring is started. It does not touch the link-change subscription; callers that
+// track PF indexes externally should refresh them from Indexes after
+// Reconcile returns.
+func (i *Interfaces) Reconcile(ctx context.Context, newCfg config.Config) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	desired := make(map[string]config.InterfaceConfig, len(newCfg.Interfaces))
+	for _, nic := range newCfg.Interfaces {
+		desired[nic.Name] = nic
+	}
+
+	for index, p := range i.PFs {
+		if _, ok := desired[p.Name]; ok {
+			continue
+		}
+
+		log.Log.Info("interface no longer configured, removing", "interface", p.Name)
+		p.StopMonitoring()
+		delete(i.PFs, index)
+	}
+
+	existing := make(map[string]struct{}, len(i.PFs))
+	for _, p := range i.PFs {
+		existing[p.Name] = struct{}{}
+	}
+
+	for _, nic := range newCfg.Interfaces {
+		if _, ok := existing[nic.Name]; ok {
+			continue
+		}
+
+		link, err := netlink.LinkByName(nic.Name)
+		if err != nil {
+			log.Log.Warn("failed to fetch interface", "interface", nic.Name, "error", err)
+			continue
+		}
+
+		log.Log.Info("adding interface", "interface", nic.Name)
+
+		p := newPF(link, newCfg.PollingInterval, newCfg.PollingMode, newCfg.Action, newCfg.ScriptPath, nic.VFPolicy, i.journal)
+		if err := p.Inspect(); err != nil {
+			log.Log.Error("interface not ready", "interface", p.Name, "error", err)
+		} else {
+			p.StartMonitoring(ctx)
+		}
+
+		i.PFs[p.Index] = p
+	}
+
+	action := newAction(newCfg.Action, newCfg.ScriptPath)
+	for _, p := range i.PFs {
+		vfPolicy := p.vfPolicy
+		if nic, ok := desired[p.Name]; ok {
+			vfPolicy = nic.VFPolicy
+		}
+
+		p.updateSettings(newCfg.PollingInterval, newCfg.PollingMode, action, vfPolicy)
+	}
+}
+
+// Pause stops monitoring the named interface without removing it from the
+// managed set, so Resume can restart it later.
+func (i *Interfaces) Pause(name string) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	p := i.findByName(name)
+	if p == nil {
+		return fmt.Errorf("interface %s is not managed", name)
+	}
+
+	p.StopMonitoring()
+
+	return nil
+}
+
+// Resume restarts monitoring the named interface after it was Paused.
+func (i *Interfaces) Resume(ctx context.Context, name string) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	p := i.findByName(name)
+	if p == nil {
+		return fmt.Errorf("interface %s is not managed", name)
+	}
+
+	if err := p.Inspect(); err != nil {
+		return fmt.Errorf("interface %s is not ready: %w", name, err)
+	}
+
+	p.StartMonitoring(ctx)
+
+	return nil
+}
+
+// List returns the name and monitoring status of each managed PF.
+func (i *Interfaces) List() map[string]bool {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	status := make(map[string]bool, len(i.PFs))
+	for _, p := range i.PFs {
+		p.mu.Lock()
+		status[p.Name] = p.Monitoring
+		p.mu.Unlock()
+	}
+
+	return status
+}
+
+// findByName returns the PF with the given name, or nil if none is managed.
+// Callers must hold mu.
+func (i *Interfaces) findByName(name string) *PF {
+	for _, p := range i.PFs {
+		if p.Name == name {
+			return p
+		}
+	}
+
+	return nil
+}
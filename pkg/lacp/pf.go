@@ -3,13 +3,23 @@ package lacp
 import (
 	"context"
 	"fmt"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/vishvananda/netlink"
 
+	"github.com/mlguerrero12/pf-status-relay/pkg/config"
+	"github.com/mlguerrero12/pf-status-relay/pkg/events"
 	"github.com/mlguerrero12/pf-status-relay/pkg/log"
+	"github.com/mlguerrero12/pf-status-relay/pkg/metrics"
 )
 
+// reconciliationInterval is how often monitorBySubscription re-fetches the
+// link as a safety net, in case a slave attribute change is missed by the
+// kernel's netlink notifications.
+const reconciliationInterval = 30 * time.Second
+
 // PF contains information about the physical function as well as a context to manage lacp monitoring.
 type PF struct {
 	// Name is the name of the interface.
@@ -26,7 +36,43 @@ type PF struct {
 	cancel     context.CancelFunc
 	endChan    chan struct{}
 
+	// mu guards Monitoring, ctx, cancel and endChan against concurrent
+	// Start/StopMonitoring calls: the link-change processing goroutine in
+	// Interfaces.Start and the Reconcile/Pause/Resume calls triggered by the
+	// control socket can both target the same PF at once.
+	mu sync.Mutex
+
+	// cfgMu guards pollingInterval, pollingMode, action and vfPolicy, which
+	// Interfaces.Reconcile replaces in place on a live config reload while
+	// the monitoring goroutine started by StartMonitoring is reading them on
+	// every cycle.
+	cfgMu           sync.Mutex
 	pollingInterval int
+	pollingMode     bool
+	action          Action
+	vfPolicy        config.VFPolicy
+	journal         *events.Journal
+
+	// lacpState tracks LACP state across monitoring iterations.
+	lacpState
+}
+
+// lacpState is the mutable state threaded through successive monitoring
+// iterations, regardless of whether they are triggered by polling or by a
+// netlink subscription.
+type lacpState struct {
+	// initialized is set once the first reconcile call has established a
+	// baseline state; that first observation is applied immediately, since
+	// DownDebounce/UpDelay exist to filter flapping, not to delay startup.
+	initialized bool
+	// rawLacpUp is the last LACP protocol state observed, before hysteresis.
+	rawLacpUp bool
+	// effectiveLacpUp is the debounced state actions are driven from.
+	effectiveLacpUp bool
+	// transitionAt is when rawLacpUp last changed.
+	transitionAt time.Time
+
+	noVFLog bool
 }
 
 func (p *PF) Inspect() error {
@@ -79,8 +125,15 @@ func (p *PF) Update() (bool, error) {
 	return true, nil
 }
 
-// StartMonitoring starts lacp monitoring.
+// StartMonitoring starts lacp monitoring. By default it subscribes to
+// bond-slave link updates so that state changes are reacted to as soon as
+// the kernel reports them; set pollingMode on the PF to fall back to the
+// legacy fixed-interval polling loop for kernels that don't emit slave
+// attribute change notifications.
 func (p *PF) StartMonitoring(ctx context.Context) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
 	if p.Monitoring {
 		log.Log.Debug("lacp monitoring has already started", "interface", p.Name)
 		return
@@ -88,6 +141,7 @@ func (p *PF) StartMonitoring(ctx context.Context) {
 
 	log.Log.Info("starting lacp monitoring", "interface", p.Name)
 	p.Monitoring = true
+	p.lacpState = lacpState{noVFLog: true}
 
 	// Context to cancel monitoring.
 	stop, cancel := context.WithCancel(ctx)
@@ -95,96 +149,309 @@ func (p *PF) StartMonitoring(ctx context.Context) {
 	p.cancel = cancel
 	p.endChan = make(chan struct{})
 
-	go func() {
-		defer func() {
-			p.endChan <- struct{}{}
-		}()
-
-		lacpUp := false
-		noVFLog := true
-		firstDownLog := true
-		for {
-			select {
-			case <-time.Tick(time.Duration(p.pollingInterval) * time.Millisecond):
-				link, err := netlink.LinkByIndex(p.Index)
-				if err != nil {
-					log.Log.Warn("failed to fetch interface", "interface", p.Name, "error", err)
-					break
-				}
-
-				// Stop if interface has no configured VFs.
-				vfs := link.Attrs().Vfs
-				if len(vfs) == 0 {
-					if noVFLog {
-						log.Log.Info("interface has no VFs", "interface", p.Name)
-						noVFLog = false
-					}
-					break
-				}
-				noVFLog = true
-
-				// Check lacp state.
-				slave := link.Attrs().Slave
-				if slave != nil {
-					s, ok := slave.(*netlink.BondSlave)
-					if !ok {
-						log.Log.Error("interface does not have BondSlave type on Slave attribute", "interface", p.Name)
-						break
-					}
-
-					if isProtocolUp(s) {
-						if !lacpUp {
-							log.Log.Info("lacp is up", "interface", p.Name)
-							lacpUp = true
-
-							if !IsFastRate(s) {
-								log.Log.Warn("partner is using slow lacp rate", "interface", p.Name)
-							}
-						}
-
-						// Bring to auto all VFs whose state is disable.
-						for _, vf := range vfs {
-							log.Log.Debug("vf info", "id", vf.ID, "state", vf.LinkState, "interface", p.Name)
-							if vf.LinkState == netlink.VF_LINK_STATE_DISABLE {
-								err = netlink.LinkSetVfState(link, vf.ID, netlink.VF_LINK_STATE_AUTO)
-								if err != nil {
-									log.Log.Error("failed to set vf link state", "id", vf.ID, "interace", p.Name, "error", err)
-								}
-								log.Log.Info("vf link state was set", "id", vf.ID, "state", "auto", "interface", p.Name)
-							}
-						}
-					} else {
-						if lacpUp || firstDownLog {
-							log.Log.Info("lacp is down", "interface", p.Name)
-							lacpUp = false
-							firstDownLog = false
-						}
-
-						// Bring to disable all VFs whose state is auto.
-						for _, vf := range vfs {
-							log.Log.Debug("vf info", "id", vf.ID, "state", vf.LinkState, "interface", p.Name)
-							if vf.LinkState == netlink.VF_LINK_STATE_AUTO {
-								err = netlink.LinkSetVfState(link, vf.ID, netlink.VF_LINK_STATE_DISABLE)
-								if err != nil {
-									log.Log.Error("failed to set vf link state", "id", vf.ID, "interface", p.Name, "error", err)
-								}
-								log.Log.Info("vf link state was set", "id", vf.ID, "state", "disable", "interface", p.Name)
-							}
-						}
-					}
-				} else {
-					log.Log.Error("interface has no slave attribute", "interface", p.Name)
-				}
-			case <-stop.Done():
-				log.Log.Debug("ctx cancelled", "routine", "monitoring")
+	p.cfgMu.Lock()
+	pollingMode := p.pollingMode
+	p.cfgMu.Unlock()
+
+	if pollingMode {
+		go p.monitorByPolling(stop)
+	} else {
+		go p.monitorBySubscription(stop)
+	}
+}
+
+// pollInterval returns the current polling interval as a time.Duration. It
+// is read through cfgMu since Interfaces.Reconcile can update it while this
+// is called from a running monitoring goroutine.
+func (p *PF) pollInterval() time.Duration {
+	p.cfgMu.Lock()
+	defer p.cfgMu.Unlock()
+
+	return time.Duration(p.pollingInterval) * time.Millisecond
+}
+
+// monitorByPolling inspects the PF's LACP/VF state every pollingInterval
+// milliseconds.
+func (p *PF) monitorByPolling(ctx context.Context) {
+	defer func() {
+		p.endChan <- struct{}{}
+	}()
+
+	for {
+		select {
+		case <-time.Tick(p.pollInterval()):
+			link, err := netlink.LinkByIndex(p.Index)
+			if err != nil {
+				log.Log.Warn("failed to fetch interface", "interface", p.Name, "error", err)
+				break
+			}
+
+			p.reconcile(link)
+		case <-ctx.Done():
+			log.Log.Debug("ctx cancelled", "routine", "monitoring")
+			return
+		}
+	}
+}
+
+// monitorBySubscription reacts to RTM_NEWLINK updates for the PF as soon as
+// the kernel emits them, falling back to a much slower reconciliation timer
+// as a safety net for updates that are missed or coalesced. If the
+// subscription cannot be established, it falls back to monitorByPolling.
+func (p *PF) monitorBySubscription(ctx context.Context) {
+	updates := make(chan netlink.LinkUpdate)
+	done := make(chan struct{})
+	defer close(done)
+
+	err := netlink.LinkSubscribeWithOptions(updates, done, netlink.LinkSubscribeOptions{
+		ListExisting: true,
+	})
+	if err != nil {
+		log.Log.Warn("failed to subscribe to link updates, falling back to polling", "interface", p.Name, "error", err)
+		p.monitorByPolling(ctx)
+		return
+	}
+
+	defer func() {
+		p.endChan <- struct{}{}
+	}()
+
+	reconcile := time.NewTicker(reconciliationInterval)
+	defer reconcile.Stop()
+
+	for {
+		select {
+		case update, ok := <-updates:
+			if !ok {
+				log.Log.Warn("link update channel closed", "interface", p.Name)
 				return
 			}
+
+			// Only react to updates for this PF.
+			if update.Link.Attrs().Index != p.Index {
+				break
+			}
+
+			p.reconcile(update.Link)
+		case <-reconcile.C:
+			link, err := netlink.LinkByIndex(p.Index)
+			if err != nil {
+				log.Log.Warn("failed to fetch interface", "interface", p.Name, "error", err)
+				break
+			}
+
+			p.reconcile(link)
+		case <-ctx.Done():
+			log.Log.Debug("ctx cancelled", "routine", "monitoring")
+			return
 		}
+	}
+}
+
+// reconcile inspects a single link snapshot, updates LACP/VF state and
+// metrics accordingly, and invokes the PF's Action for each VF group on
+// every call, according to the current effective LACP state.
+func (p *PF) reconcile(link netlink.Link) {
+	start := time.Now()
+	defer func() {
+		metrics.PollingLatency.WithLabelValues(p.Name).Observe(time.Since(start).Seconds())
 	}()
+
+	// Stop if interface has no configured VFs.
+	vfs := link.Attrs().Vfs
+	if len(vfs) == 0 {
+		if p.noVFLog {
+			log.Log.Info("interface has no VFs", "interface", p.Name)
+			p.noVFLog = false
+		}
+		return
+	}
+	p.noVFLog = true
+
+	// Check lacp state.
+	slave := link.Attrs().Slave
+	if slave == nil {
+		log.Log.Error("interface has no slave attribute", "interface", p.Name)
+		return
+	}
+
+	s, ok := slave.(*netlink.BondSlave)
+	if !ok {
+		log.Log.Error("interface does not have BondSlave type on Slave attribute", "interface", p.Name)
+		return
+	}
+
+	edge, up := p.observeLacpState(isProtocolUp(s))
+	if edge {
+		p.logEdge(up)
+
+		if up {
+			if !IsFastRate(s) {
+				log.Log.Warn("partner is using slow lacp rate", "interface", p.Name)
+				metrics.LacpFastRate.WithLabelValues(p.Name).Set(0)
+			} else {
+				metrics.LacpFastRate.WithLabelValues(p.Name).Set(1)
+			}
+		}
+	}
+
+	p.cfgMu.Lock()
+	action := p.action
+	excludeVFs := p.vfPolicy.ExcludeVFs
+	groups := p.vfPolicy.Groups
+	p.cfgMu.Unlock()
+
+	acted := filterExcluded(vfs, excludeVFs)
+	for name, group := range groupVFs(acted, groups) {
+		if len(group) == 0 {
+			continue
+		}
+
+		var err error
+		if up {
+			err = action.OnLacpUp(p, group)
+		} else {
+			err = action.OnLacpDown(p, group)
+		}
+		if err != nil {
+			log.Log.Error("lacp action failed", "interface", p.Name, "group", name, "error", err)
+		}
+	}
+
+	for _, vf := range vfs {
+		log.Log.Debug("vf info", "id", vf.ID, "state", vf.LinkState, "interface", p.Name)
+		metrics.VfLinkState.WithLabelValues(p.Name, strconv.Itoa(vf.ID)).Set(vfLinkStateValue(vf.LinkState))
+	}
+}
+
+// observeLacpState feeds a freshly observed LACP protocol state through the
+// PF's hysteresis settings and returns whether the effective (debounced)
+// state changed on this call, along with its new value. The first
+// observation after StartMonitoring is always applied immediately: debounce
+// and delay exist to filter flapping, not to delay startup.
+//
+// Because the hold period is only re-checked when reconcile runs again, a
+// transition takes effect on the first reconcile call at or after
+// transitionAt+hold, which under subscription mode may lag by up to
+// reconciliationInterval if no other link update arrives sooner.
+func (p *PF) observeLacpState(observed bool) (edge bool, up bool) {
+	now := time.Now()
+
+	if !p.initialized {
+		p.initialized = true
+		p.rawLacpUp = observed
+		p.effectiveLacpUp = observed
+		p.transitionAt = now
+		return true, observed
+	}
+
+	if observed != p.rawLacpUp {
+		p.rawLacpUp = observed
+		p.transitionAt = now
+	}
+
+	if p.rawLacpUp == p.effectiveLacpUp {
+		return false, p.effectiveLacpUp
+	}
+
+	p.cfgMu.Lock()
+	downDebounce, upDelay := p.vfPolicy.DownDebounce, p.vfPolicy.UpDelay
+	p.cfgMu.Unlock()
+
+	hold := downDebounce
+	if p.rawLacpUp {
+		hold = upDelay
+	}
+
+	if now.Sub(p.transitionAt) < hold {
+		return false, p.effectiveLacpUp
+	}
+
+	p.effectiveLacpUp = p.rawLacpUp
+	return true, p.effectiveLacpUp
+}
+
+// logEdge records an effective LACP state transition to the logs, metrics
+// and event journal.
+func (p *PF) logEdge(up bool) {
+	if up {
+		log.Log.Info("lacp is up", "interface", p.Name)
+		metrics.LacpUp.WithLabelValues(p.Name).Set(1)
+		metrics.StateTransitionsTotal.WithLabelValues(p.Name).Inc()
+		p.journal.Record(events.Event{Interface: p.Name, Type: events.TypeLacpUp, Before: "down", After: "up"})
+		return
+	}
+
+	log.Log.Info("lacp is down", "interface", p.Name)
+	metrics.LacpUp.WithLabelValues(p.Name).Set(0)
+	metrics.StateTransitionsTotal.WithLabelValues(p.Name).Inc()
+	p.journal.Record(events.Event{Interface: p.Name, Type: events.TypeLacpDown, Before: "up", After: "down"})
+}
+
+// filterExcluded drops VFs listed in exclude, e.g. ones assigned to a
+// management workload that should never be toggled.
+func filterExcluded(vfs []netlink.VfInfo, exclude []int) []netlink.VfInfo {
+	if len(exclude) == 0 {
+		return vfs
+	}
+
+	skip := make(map[int]struct{}, len(exclude))
+	for _, id := range exclude {
+		skip[id] = struct{}{}
+	}
+
+	filtered := make([]netlink.VfInfo, 0, len(vfs))
+	for _, vf := range vfs {
+		if _, ok := skip[vf.ID]; ok {
+			continue
+		}
+		filtered = append(filtered, vf)
+	}
+
+	return filtered
+}
+
+// groupVFs partitions vfs by the named groups in groups, so the Action can
+// be invoked once per group and react to each independently. VFs not listed
+// in any group fall into a catch-all group keyed by the empty string.
+func groupVFs(vfs []netlink.VfInfo, groups map[string][]int) map[string][]netlink.VfInfo {
+	if len(groups) == 0 {
+		return map[string][]netlink.VfInfo{"": vfs}
+	}
+
+	memberOf := make(map[int]string, len(vfs))
+	for name, ids := range groups {
+		for _, id := range ids {
+			memberOf[id] = name
+		}
+	}
+
+	grouped := make(map[string][]netlink.VfInfo, len(groups)+1)
+	for _, vf := range vfs {
+		grouped[memberOf[vf.ID]] = append(grouped[memberOf[vf.ID]], vf)
+	}
+
+	return grouped
+}
+
+// updateSettings atomically replaces the PF's live-reloadable settings, so
+// Interfaces.Reconcile can apply a config reload without racing the
+// monitoring goroutine's reads of them.
+func (p *PF) updateSettings(pollingInterval int, pollingMode bool, action Action, vfPolicy config.VFPolicy) {
+	p.cfgMu.Lock()
+	defer p.cfgMu.Unlock()
+
+	p.pollingInterval = pollingInterval
+	p.pollingMode = pollingMode
+	p.action = action
+	p.vfPolicy = vfPolicy
 }
 
 // StopMonitoring stops lacp monitoring.
 func (p *PF) StopMonitoring() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
 	if !p.Monitoring {
 		return
 	}
@@ -194,4 +461,15 @@ func (p *PF) StopMonitoring() {
 	<-p.endChan
 
 	p.Monitoring = false
+	metrics.Unregister(p.Name)
+}
+
+// vfLinkStateValue maps a netlink VF link state to the 0/1 value exposed on
+// the VfLinkState gauge: 1 when the VF is enabled (auto or enable), 0 when
+// disabled.
+func vfLinkStateValue(state uint32) float64 {
+	if state == netlink.VF_LINK_STATE_DISABLE {
+		return 0
+	}
+	return 1
 }
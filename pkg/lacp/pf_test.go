@@ -0,0 +1,102 @@
+package lacp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/vishvananda/netlink"
+
+	"github.com/mlguerrero12/pf-status-relay/pkg/config"
+)
+
+func TestObserveLacpStateFirstObservationAppliedImmediately(t *testing.T) {
+	p := &PF{}
+
+	edge, up := p.observeLacpState(true)
+	if !edge || !up {
+		t.Fatalf("got edge=%v up=%v, want edge=true up=true", edge, up)
+	}
+}
+
+func TestObserveLacpStateFlipHeldBelowHoldDoesNotTakeEffect(t *testing.T) {
+	p := &PF{}
+	p.vfPolicy = config.VFPolicy{DownDebounce: time.Hour}
+
+	p.observeLacpState(true)
+
+	edge, up := p.observeLacpState(false)
+	if edge || !up {
+		t.Fatalf("got edge=%v up=%v, want edge=false up=true", edge, up)
+	}
+}
+
+func TestObserveLacpStateFlipPastDownDebounceTakesEffect(t *testing.T) {
+	p := &PF{}
+	p.vfPolicy = config.VFPolicy{DownDebounce: time.Millisecond}
+
+	p.observeLacpState(true)
+	p.observeLacpState(false)
+
+	time.Sleep(5 * time.Millisecond)
+
+	edge, up := p.observeLacpState(false)
+	if !edge || up {
+		t.Fatalf("got edge=%v up=%v, want edge=true up=false", edge, up)
+	}
+}
+
+func TestObserveLacpStateFlipPastUpDelayTakesEffect(t *testing.T) {
+	p := &PF{}
+	p.vfPolicy = config.VFPolicy{UpDelay: time.Millisecond}
+
+	p.observeLacpState(false)
+	p.observeLacpState(true)
+
+	time.Sleep(5 * time.Millisecond)
+
+	edge, up := p.observeLacpState(true)
+	if !edge || !up {
+		t.Fatalf("got edge=%v up=%v, want edge=true up=true", edge, up)
+	}
+}
+
+func TestFilterExcluded(t *testing.T) {
+	vfs := []netlink.VfInfo{{ID: 0}, {ID: 1}, {ID: 2}}
+
+	got := filterExcluded(vfs, []int{1})
+	if len(got) != 2 || got[0].ID != 0 || got[1].ID != 2 {
+		t.Fatalf("got %+v, want vfs 0 and 2", got)
+	}
+}
+
+func TestFilterExcludedNoExclusions(t *testing.T) {
+	vfs := []netlink.VfInfo{{ID: 0}, {ID: 1}}
+
+	got := filterExcluded(vfs, nil)
+	if len(got) != len(vfs) {
+		t.Fatalf("got %d vfs, want %d", len(got), len(vfs))
+	}
+}
+
+func TestGroupVFs(t *testing.T) {
+	vfs := []netlink.VfInfo{{ID: 0}, {ID: 1}, {ID: 2}}
+	groups := map[string][]int{"trusted": {0, 1}}
+
+	got := groupVFs(vfs, groups)
+
+	if len(got["trusted"]) != 2 {
+		t.Fatalf("group trusted: got %d vfs, want 2", len(got["trusted"]))
+	}
+	if len(got[""]) != 1 || got[""][0].ID != 2 {
+		t.Fatalf("catch-all group: got %+v, want vf 2", got[""])
+	}
+}
+
+func TestGroupVFsNoGroupsReturnsCatchAll(t *testing.T) {
+	vfs := []netlink.VfInfo{{ID: 0}, {ID: 1}}
+
+	got := groupVFs(vfs, nil)
+	if len(got) != 1 || len(got[""]) != len(vfs) {
+		t.Fatalf("got %+v, want a single catch-all group with all vfs", got)
+	}
+}
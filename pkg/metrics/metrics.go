@@ -0,0 +1,90 @@
+// Package metrics exposes Prometheus metrics describing the LACP and VF
+// state observed by pkg/lacp, served over HTTP for scraping.
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/mlguerrero12/pf-status-relay/pkg/log"
+)
+
+const namespace = "pf_status_relay"
+
+var (
+	// LacpUp is 1 when the partner is reporting LACP up on the PF, 0 otherwise.
+	LacpUp = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "lacp_up",
+		Help:      "Whether LACP is up (1) or down (0) on the PF.",
+	}, []string{"interface"})
+
+	// LacpFastRate is 1 when the partner is using the fast LACP rate, 0 when slow.
+	LacpFastRate = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "lacp_fast_rate",
+		Help:      "Whether the LACP partner is using the fast rate (1) or slow rate (0).",
+	}, []string{"interface"})
+
+	// VfLinkState is 1 when a VF's link state is auto/enabled, 0 when disabled.
+	VfLinkState = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "vf_link_state",
+		Help:      "Whether the VF link state is enabled (1) or disabled (0).",
+	}, []string{"interface", "vf_id"})
+
+	// StateTransitionsTotal counts LACP up/down edges observed on the PF.
+	StateTransitionsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "lacp_state_transitions_total",
+		Help:      "Total number of LACP up/down state transitions observed.",
+	}, []string{"interface"})
+
+	// VfActionFailuresTotal counts failed attempts by an Action to react to a
+	// LACP edge, labeled by which action backend failed (vfstate, trust,
+	// spoofcheck, rate or script).
+	VfActionFailuresTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "vf_action_failures_total",
+		Help:      "Total number of failed attempts by an action to react to a LACP edge, by action.",
+	}, []string{"interface", "action"})
+
+	// PollingLatency observes how long a single polling cycle took.
+	PollingLatency = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "polling_latency_seconds",
+		Help:      "Latency of a single LACP/VF polling cycle.",
+	}, []string{"interface"})
+)
+
+// Start serves the metrics registry over HTTP at address, returning only
+// if the server fails. It is meant to be run in its own goroutine.
+func Start(address string) {
+	if address == "" {
+		log.Log.Debug("metrics address not configured, metrics endpoint disabled")
+		return
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+
+	log.Log.Info("starting metrics endpoint", "address", address)
+	if err := http.ListenAndServe(address, mux); err != nil {
+		log.Log.Error("metrics endpoint stopped", "error", err)
+	}
+}
+
+// Unregister removes all series associated with an interface whose
+// monitoring has stopped, so a restarted or re-added PF starts from a clean
+// state.
+func Unregister(name string) {
+	LacpUp.DeleteLabelValues(name)
+	LacpFastRate.DeleteLabelValues(name)
+	StateTransitionsTotal.DeleteLabelValues(name)
+	PollingLatency.DeleteLabelValues(name)
+	VfLinkState.DeletePartialMatch(prometheus.Labels{"interface": name})
+	VfActionFailuresTotal.DeletePartialMatch(prometheus.Labels{"interface": name})
+}